@@ -0,0 +1,91 @@
+package in_toto
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeCanonicalOrdersKeysAndOmitsWhitespace(t *testing.T) {
+	data := map[string]interface{}{
+		"b": 1,
+		"a": 2,
+		"c": map[string]interface{}{"z": 1, "y": 2},
+	}
+
+	got, err := encodeCanonical(data)
+	if err != nil {
+		t.Fatalf("encodeCanonical() failed: %v", err)
+	}
+
+	want := `{"a":2,"b":1,"c":{"y":2,"z":1}}`
+	if string(got) != want {
+		t.Errorf("encodeCanonical() = %q, want %q", got, want)
+	}
+}
+
+func TestCanonicalizersAreDeterministic(t *testing.T) {
+	data := map[string]interface{}{"b": "two", "a": "one"}
+
+	canonicalizers := []struct {
+		name string
+		c    Canonicalizer
+	}{
+		{"OLPC", OLPCCanonicalizer{}},
+		{"Docker", DockerCanonicalizer{}},
+		{"JCS", JCSCanonicalizer{}},
+	}
+
+	for _, table := range canonicalizers {
+		t.Run(table.name, func(t *testing.T) {
+			first, err := table.c.Canonicalize(data)
+			if err != nil {
+				t.Fatalf("Canonicalize() failed: %v", err)
+			}
+			second, err := table.c.Canonicalize(data)
+			if err != nil {
+				t.Fatalf("Canonicalize() failed on second call: %v", err)
+			}
+			if string(first) != string(second) {
+				t.Errorf("Canonicalize() is not deterministic: %q != %q", first, second)
+			}
+		})
+	}
+}
+
+func TestMetablockDumpAsLoadAsCBORRoundTrip(t *testing.T) {
+	link := Link{
+		Type: "link",
+		Name: "build",
+		Materials: map[string]interface{}{
+			"src.tar.gz": map[string]interface{}{"sha256": "abc123"},
+		},
+		Products: map[string]interface{}{
+			"out.tar.gz": map[string]interface{}{"sha256": "def456"},
+		},
+	}
+	mb := Metablock{
+		Signed:     link,
+		Signatures: []Signature{{KeyId: "abc", Sig: "def"}},
+	}
+
+	path := filepath.Join(t.TempDir(), "link.cbor")
+	if err := mb.DumpAs(path, FormatCBOR); err != nil {
+		t.Fatalf("DumpAs(FormatCBOR) failed: %v", err)
+	}
+
+	var loaded Metablock
+	if err := loaded.LoadAs(path, FormatCBOR); err != nil {
+		t.Fatalf("LoadAs(FormatCBOR) failed: %v", err)
+	}
+
+	loadedLink, ok := loaded.Signed.(Link)
+	if !ok {
+		t.Fatalf("loaded.Signed is %T, want Link", loaded.Signed)
+	}
+	if loadedLink.Name != link.Name {
+		t.Errorf("loaded link name = %q, want %q", loadedLink.Name, link.Name)
+	}
+	if len(loaded.Signatures) != 1 || loaded.Signatures[0].KeyId != "abc" {
+		t.Errorf("loaded signatures = %+v, want one signature with keyid 'abc'", loaded.Signatures)
+	}
+}
@@ -0,0 +1,269 @@
+package in_toto
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+/*
+Delegation grants a named subset of functionaries, identified by KeyIDs,
+authority over the steps whose names match Paths, modeled on TUF's targets
+delegations. It lets a Layout scale authority to a subset of functionaries
+without requiring every Step to enumerate every permitted key itself.
+*/
+type Delegation struct {
+	Name        string   `json:"name"`
+	KeyIDs      []string `json:"keyids"`
+	Threshold   int      `json:"threshold"`
+	Paths       []string `json:"paths"`
+	Terminating bool     `json:"terminating"`
+}
+
+/*
+SuccinctRoles deterministically picks one of 2^BitLength role names for a
+step, so a Layout does not have to enumerate a Delegation per step, modeled
+on TUF's succinct roles. Unlike a Delegation, every bin is authorized by the
+same KeyIDs/Threshold: BitLength only changes how many distinct role names
+(and therefore audit trail entries) a verifier can distinguish between, it
+does not partition KeyIDs itself.
+*/
+type SuccinctRoles struct {
+	KeyIDs     []string `json:"keyids"`
+	Threshold  int      `json:"threshold"`
+	BitLength  uint8    `json:"bit_length"`
+	NamePrefix string   `json:"name_prefix"`
+}
+
+/*
+RoleName returns the succinct role name responsible for the passed bin,
+i.e. NamePrefix followed by the bin index in lowercase hex, left-padded to
+the number of hex digits BitLength requires.
+*/
+func (sr SuccinctRoles) RoleName(bin uint32) string {
+	hexDigits := (int(sr.BitLength) + 3) / 4
+	return fmt.Sprintf("%s-%0*x", sr.NamePrefix, hexDigits, bin)
+}
+
+/*
+bin maps stepName to one of this SuccinctRoles' 2^BitLength bins, by
+hashing stepName with SHA-256 and taking the leading BitLength bits of the
+digest as the bin index. The bin only picks a role name for audit purposes;
+every bin shares the same KeyIDs and Threshold, see RoleName and
+SuccinctRoles.
+*/
+func (sr SuccinctRoles) bin(stepName string) uint32 {
+	digest := sha256.Sum256([]byte(stepName))
+
+	// Take the first 4 bytes of the digest as a big-endian uint32, then
+	// keep only the leading BitLength bits.
+	full := uint32(digest[0])<<24 | uint32(digest[1])<<16 | uint32(digest[2])<<8 | uint32(digest[3])
+	return full >> (32 - uint(sr.BitLength))
+}
+
+/*
+maxBitLength is the largest BitLength this package supports. It is capped at
+31, one short of the 32 bits bin() actually extracts from the SHA-256
+digest, so that 1<<BitLength always fits in a uint32 and never overflows to
+0 the way 1<<32 would.
+*/
+func (sr SuccinctRoles) maxBitLength() uint8 {
+	return 31
+}
+
+/*
+functionaries returns the Keys and Threshold a SuccinctRoles grants to
+stepName's role, and the resolved role name for error messages/auditing. It
+validates BitLength and Threshold first, since both describe shared,
+step-independent invariants of the SuccinctRoles rather than anything
+specific to stepName.
+*/
+func (sr SuccinctRoles) functionaries(stepName string, keys map[string]Key) ([]Key, int, string, error) {
+	if sr.BitLength == 0 || sr.BitLength > sr.maxBitLength() {
+		return nil, 0, "", fmt.Errorf("succinct roles bit_length must be between 1 and %d", sr.maxBitLength())
+	}
+	if sr.Threshold > len(sr.KeyIDs) {
+		return nil, 0, "", fmt.Errorf("succinct roles threshold %d exceeds %d available keyids",
+			sr.Threshold, len(sr.KeyIDs))
+	}
+
+	roleName := sr.RoleName(sr.bin(stepName))
+
+	resolved := make([]Key, 0, len(sr.KeyIDs))
+	for _, keyID := range sr.KeyIDs {
+		key, ok := keys[keyID]
+		if !ok {
+			return nil, 0, "", fmt.Errorf("succinct role '%s' keyid '%s' not found in layout keys",
+				roleName, keyID)
+		}
+		resolved = append(resolved, key)
+	}
+
+	return resolved, sr.Threshold, roleName, nil
+}
+
+/*
+flatFunctionaries returns every Key in the Layout's flat Keys map together
+with the Threshold the Step named stepName declares, or 1 if no Step has that
+name (e.g. for an Inspection), which is the zero-delegation,
+zero-succinct-roles default that keeps pre-delegation Layouts working
+unchanged.
+*/
+func (l *Layout) flatFunctionaries(stepName string) ([]Key, int) {
+	keys := make([]Key, 0, len(l.Keys))
+	for _, key := range l.Keys {
+		keys = append(keys, key)
+	}
+
+	threshold := 1
+	for _, step := range l.Steps {
+		if step.Name == stepName {
+			threshold = step.Threshold
+			break
+		}
+	}
+
+	return keys, threshold
+}
+
+/*
+matchingDelegation returns the Keys and Threshold of the first Delegation in
+l.Delegations whose Paths match stepName, its Terminating flag, and whether
+any Delegation matched at all.
+*/
+func (l *Layout) matchingDelegation(stepName string) (keys []Key, threshold int, terminating bool, matched bool, err error) {
+	for _, delegation := range l.Delegations {
+		pathMatched := false
+		for _, pattern := range delegation.Paths {
+			if NewSet(stepName).Filter(pattern).Has(stepName) {
+				pathMatched = true
+				break
+			}
+		}
+		if !pathMatched {
+			continue
+		}
+
+		resolved := make([]Key, 0, len(delegation.KeyIDs))
+		for _, keyID := range delegation.KeyIDs {
+			key, ok := l.Keys[keyID]
+			if !ok {
+				return nil, 0, false, false, fmt.Errorf(
+					"delegation '%s' keyid '%s' not found in layout keys", delegation.Name, keyID)
+			}
+			resolved = append(resolved, key)
+		}
+
+		return resolved, delegation.Threshold, delegation.Terminating, true, nil
+	}
+
+	return nil, 0, false, false, nil
+}
+
+/*
+ResolveFunctionaries returns the Keys authorized to sign off on the Step or
+Inspection named stepName, together with the Threshold of valid signatures
+required from them, by walking the Layout's Delegations top-down and, if
+present, consulting SuccinctRoles. If the Layout defines no Delegations and
+no SuccinctRoles, it falls back to the existing flat Keys map and whatever
+Threshold the matching Step declares, which keeps pre-delegation Layouts
+working unchanged.
+
+ResolveFunctionaries reports only the first matching Delegation; it does not
+know whether that Delegation's Threshold will actually be met by a given set
+of signatures. To walk past a matching, non-Terminating Delegation whose
+Threshold a Metablock's signatures fail to reach, use VerifyLinkSignatures.
+*/
+func (l *Layout) ResolveFunctionaries(stepName string) ([]Key, int, error) {
+	keys, threshold, _, matched, err := l.matchingDelegation(stepName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if matched {
+		return keys, threshold, nil
+	}
+
+	if l.SuccinctRoles != nil {
+		keys, threshold, _, err := l.SuccinctRoles.functionaries(stepName, l.Keys)
+		return keys, threshold, err
+	}
+
+	keys, threshold = l.flatFunctionaries(stepName)
+	return keys, threshold, nil
+}
+
+/*
+VerifyLinkSignatures checks whether mb (expected to wrap a signed Link)
+carries at least Threshold valid signatures from the functionaries
+authorized for the Step or Inspection named stepName, walking l.Delegations
+top-down. Unlike ResolveFunctionaries, it keeps walking past a matching
+Delegation whose Threshold is not met as long as that Delegation is not
+Terminating: Terminating is what stops the walk and fails verification
+outright, not the act of matching Paths. If no Delegation matches (or every
+match is non-Terminating and none reaches its Threshold), SuccinctRoles is
+consulted next, and finally the flat Keys map with the matching Step's own
+Threshold, mirroring ResolveFunctionaries' zero-delegation default.
+*/
+func (l *Layout) VerifyLinkSignatures(stepName string, mb Metablock) error {
+	for _, delegation := range l.Delegations {
+		pathMatched := false
+		for _, pattern := range delegation.Paths {
+			if NewSet(stepName).Filter(pattern).Has(stepName) {
+				pathMatched = true
+				break
+			}
+		}
+		if !pathMatched {
+			continue
+		}
+
+		keys := make([]Key, 0, len(delegation.KeyIDs))
+		for _, keyID := range delegation.KeyIDs {
+			key, ok := l.Keys[keyID]
+			if !ok {
+				return fmt.Errorf("delegation '%s' keyid '%s' not found in layout keys",
+					delegation.Name, keyID)
+			}
+			keys = append(keys, key)
+		}
+
+		if countValidSignatures(mb, keys) >= delegation.Threshold {
+			return nil
+		}
+		if delegation.Terminating {
+			return fmt.Errorf("step '%s' did not reach delegation '%s' threshold of %d valid signatures",
+				stepName, delegation.Name, delegation.Threshold)
+		}
+		// Not terminating: a failed match does not end the walk, try the
+		// next matching Delegation.
+	}
+
+	if l.SuccinctRoles != nil {
+		keys, threshold, roleName, err := l.SuccinctRoles.functionaries(stepName, l.Keys)
+		if err != nil {
+			return err
+		}
+		if countValidSignatures(mb, keys) >= threshold {
+			return nil
+		}
+		return fmt.Errorf("step '%s' did not reach succinct role '%s' threshold of %d valid signatures",
+			stepName, roleName, threshold)
+	}
+
+	keys, threshold := l.flatFunctionaries(stepName)
+	if countValidSignatures(mb, keys) >= threshold {
+		return nil
+	}
+	return fmt.Errorf("step '%s' did not reach threshold of %d valid signatures", stepName, threshold)
+}
+
+// countValidSignatures returns how many of the passed keys produced a valid
+// signature on mb.
+func countValidSignatures(mb Metablock, keys []Key) int {
+	count := 0
+	for _, key := range keys {
+		if mb.VerifySignature(key) == nil {
+			count++
+		}
+	}
+	return count
+}
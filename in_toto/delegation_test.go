@@ -0,0 +1,209 @@
+package in_toto
+
+import "testing"
+
+func TestSuccinctRolesRoleName(t *testing.T) {
+	sr := SuccinctRoles{BitLength: 10, NamePrefix: "bins"}
+
+	tables := []struct {
+		bin  uint32
+		want string
+	}{
+		{0, "bins-000"},
+		{1, "bins-001"},
+		{1023, "bins-3ff"},
+	}
+
+	for _, table := range tables {
+		if got := sr.RoleName(table.bin); got != table.want {
+			t.Errorf("RoleName(%d) = %q, want %q", table.bin, got, table.want)
+		}
+	}
+}
+
+func TestSuccinctRolesBinIsWithinRange(t *testing.T) {
+	sr := SuccinctRoles{BitLength: 4}
+	numBins := uint32(1) << sr.BitLength
+
+	stepNames := []string{"build", "test", "package", "deploy", "lint"}
+	for _, name := range stepNames {
+		bin := sr.bin(name)
+		if bin >= numBins {
+			t.Errorf("bin(%q) = %d, want < %d", name, bin, numBins)
+		}
+	}
+}
+
+func TestResolveFunctionariesSuccinctRolesGrantsTheFullKeysetToEveryBin(t *testing.T) {
+	keyIDs := []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"}
+	keys := make(map[string]Key, len(keyIDs))
+	for _, id := range keyIDs {
+		keys[id] = Key{KeyId: id}
+	}
+
+	layout := Layout{
+		Keys: keys,
+		SuccinctRoles: &SuccinctRoles{
+			KeyIDs:     keyIDs,
+			Threshold:  2,
+			BitLength:  2,
+			NamePrefix: "bins",
+		},
+	}
+
+	// Different step names land in different bins (i.e. different role
+	// names), but every bin is authorized by the same KeyIDs: succinct
+	// roles only partitions role *names*, not keysets, so a Threshold > 1
+	// stays satisfiable regardless of which bin a step lands in.
+	for _, stepName := range []string{"build", "test", "package"} {
+		resolved, threshold, err := layout.ResolveFunctionaries(stepName)
+		if err != nil {
+			t.Fatalf("ResolveFunctionaries(%q) failed: %v", stepName, err)
+		}
+		if threshold != 2 {
+			t.Errorf("threshold = %d, want 2", threshold)
+		}
+		if len(resolved) != len(keyIDs) {
+			t.Errorf("ResolveFunctionaries(%q) returned %d keys, want all %d",
+				stepName, len(resolved), len(keyIDs))
+		}
+	}
+}
+
+func TestResolveFunctionariesSuccinctRolesRejectsBitLengthOf32(t *testing.T) {
+	layout := Layout{
+		Keys: map[string]Key{"k0": {KeyId: "k0"}},
+		SuccinctRoles: &SuccinctRoles{
+			KeyIDs:    []string{"k0"},
+			Threshold: 1,
+			BitLength: 32,
+		},
+	}
+
+	if _, _, err := layout.ResolveFunctionaries("build"); err == nil {
+		t.Errorf("ResolveFunctionaries() succeeded with bit_length 32, want error (1<<32 overflows uint32 to 0)")
+	}
+}
+
+func TestResolveFunctionariesSuccinctRolesRejectsThresholdAboveKeyCount(t *testing.T) {
+	layout := Layout{
+		Keys: map[string]Key{"k0": {KeyId: "k0"}},
+		SuccinctRoles: &SuccinctRoles{
+			KeyIDs:    []string{"k0"},
+			Threshold: 2,
+			BitLength: 1,
+		},
+	}
+
+	if _, _, err := layout.ResolveFunctionaries("build"); err == nil {
+		t.Errorf("ResolveFunctionaries() succeeded with threshold > len(keyids), want error")
+	}
+}
+
+func TestResolveFunctionariesDelegationFirstMatchWins(t *testing.T) {
+	layout := Layout{
+		Keys: map[string]Key{
+			"k1": {KeyId: "k1"},
+			"k2": {KeyId: "k2"},
+		},
+		Delegations: []Delegation{
+			{Name: "first", KeyIDs: []string{"k1"}, Threshold: 1, Paths: []string{"build"}},
+			{Name: "second", KeyIDs: []string{"k2"}, Threshold: 2, Paths: []string{"build"}},
+		},
+	}
+
+	keys, threshold, err := layout.ResolveFunctionaries("build")
+	if err != nil {
+		t.Fatalf("ResolveFunctionaries() failed: %v", err)
+	}
+	if threshold != 1 {
+		t.Errorf("threshold = %d, want 1 (from the first matching delegation)", threshold)
+	}
+	if len(keys) != 1 || keys[0].KeyId != "k1" {
+		t.Errorf("keys = %+v, want just k1 (from the first matching delegation)", keys)
+	}
+}
+
+func TestVerifyLinkSignaturesFallsThroughNonTerminatingDelegation(t *testing.T) {
+	firstKey := rsaTestKey(t)
+	secondKey := rsaTestKey(t)
+	// rsaTestKey always uses the same KeyId; give each key its own.
+	firstKey.KeyId = "first-rsa-key"
+	secondKey.KeyId = "second-rsa-key"
+
+	layout := Layout{
+		Keys: map[string]Key{
+			firstKey.KeyId:  firstKey,
+			secondKey.KeyId: secondKey,
+		},
+		Delegations: []Delegation{
+			// Unsatisfied (only secondKey signs the link) and not
+			// Terminating, so verification must fall through to the
+			// second delegation rather than failing outright.
+			{Name: "first", KeyIDs: []string{firstKey.KeyId}, Threshold: 1, Paths: []string{"build"}, Terminating: false},
+			{Name: "second", KeyIDs: []string{secondKey.KeyId}, Threshold: 1, Paths: []string{"build"}},
+		},
+	}
+
+	link := Link{Type: "link", Name: "build"}
+	mb := Metablock{Signed: link}
+	if err := mb.Sign(secondKey); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+
+	if err := layout.VerifyLinkSignatures("build", mb); err != nil {
+		t.Errorf("VerifyLinkSignatures() failed even though the second delegation's key signed: %v", err)
+	}
+}
+
+func TestVerifyLinkSignaturesStopsAtTerminatingDelegation(t *testing.T) {
+	firstKey := rsaTestKey(t)
+	secondKey := rsaTestKey(t)
+	// rsaTestKey always uses the same KeyId; give each key its own.
+	firstKey.KeyId = "first-rsa-key"
+	secondKey.KeyId = "second-rsa-key"
+
+	layout := Layout{
+		Keys: map[string]Key{
+			firstKey.KeyId:  firstKey,
+			secondKey.KeyId: secondKey,
+		},
+		Delegations: []Delegation{
+			{Name: "first", KeyIDs: []string{firstKey.KeyId}, Threshold: 1, Paths: []string{"build"}, Terminating: true},
+			{Name: "second", KeyIDs: []string{secondKey.KeyId}, Threshold: 1, Paths: []string{"build"}},
+		},
+	}
+
+	link := Link{Type: "link", Name: "build"}
+	mb := Metablock{Signed: link}
+	if err := mb.Sign(secondKey); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+
+	// The first delegation matches "build", is Terminating, and its
+	// Threshold is not met by a signature from the *second* delegation's
+	// key, so verification must fail rather than fall through.
+	if err := layout.VerifyLinkSignatures("build", mb); err == nil {
+		t.Errorf("VerifyLinkSignatures() succeeded past a Terminating delegation that was not satisfied, want error")
+	}
+}
+
+func TestResolveFunctionariesFallsBackToFlatKeys(t *testing.T) {
+	layout := Layout{
+		Keys: map[string]Key{"k1": {KeyId: "k1"}},
+		Steps: []Step{
+			{SupplyChainItem: SupplyChainItem{Name: "build"}, Threshold: 1},
+		},
+	}
+
+	keys, threshold, err := layout.ResolveFunctionaries("build")
+	if err != nil {
+		t.Fatalf("ResolveFunctionaries() failed: %v", err)
+	}
+	if threshold != 1 {
+		t.Errorf("threshold = %d, want 1", threshold)
+	}
+	if len(keys) != 1 || keys[0].KeyId != "k1" {
+		t.Errorf("keys = %+v, want just k1", keys)
+	}
+}
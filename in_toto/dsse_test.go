@@ -0,0 +1,159 @@
+package in_toto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+)
+
+func TestPreAuthenticationEncoding(t *testing.T) {
+	tables := []struct {
+		name        string
+		payloadType string
+		payload     []byte
+		want        string
+	}{
+		{"empty payload", "http://example.com/HelloWorld", []byte(""),
+			"DSSEv1 29 http://example.com/HelloWorld 0 "},
+		{"non-empty payload", "http://example.com/HelloWorld", []byte("hello"),
+			"DSSEv1 29 http://example.com/HelloWorld 5 hello"},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			got := preAuthenticationEncoding(table.payloadType, table.payload)
+			if string(got) != table.want {
+				t.Errorf("preAuthenticationEncoding() = %q, want %q", got, table.want)
+			}
+		})
+	}
+}
+
+func newTestRSAKey(t *testing.T) Key {
+	t.Helper()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	privPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(priv),
+	})
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal RSA public key: %v", err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pubBytes,
+	})
+
+	return Key{
+		KeyId:   hex.EncodeToString([]byte("test-rsa-key")),
+		KeyType: "rsa",
+		Scheme:  "rsassa-pss-sha256",
+		KeyVal: KeyVal{
+			Private: string(privPEM),
+			Public:  string(pubPEM),
+		},
+	}
+}
+
+func TestEnvelopeSignVerifyRoundTrip(t *testing.T) {
+	key := newTestRSAKey(t)
+
+	env := Envelope{
+		PayloadType: PayloadTypeLink,
+		Payload:     []byte(`{"_type":"link","name":"test"}`),
+	}
+
+	if err := env.Sign(key); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+
+	if len(env.Signatures) != 1 {
+		t.Fatalf("expected 1 signature, got %d", len(env.Signatures))
+	}
+
+	// Per the DSSE spec, EnvelopeSignature.Sig must be base64 of the raw
+	// signature bytes, not the hex this package's Signers produce.
+	if _, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig); err != nil {
+		t.Errorf("Signatures[0].Sig = %q is not valid base64: %v", env.Signatures[0].Sig, err)
+	}
+	if _, err := hex.DecodeString(env.Signatures[0].Sig); err == nil {
+		t.Errorf("Signatures[0].Sig = %q looks like hex, want base64 per the DSSE spec", env.Signatures[0].Sig)
+	}
+
+	if err := env.VerifySignature(key); err != nil {
+		t.Errorf("VerifySignature() failed on a freshly signed envelope: %v", err)
+	}
+
+	// Tampering with the payload after signing must invalidate the signature.
+	env.Payload = []byte(`{"_type":"link","name":"tampered"}`)
+	if err := env.VerifySignature(key); err == nil {
+		t.Errorf("VerifySignature() succeeded on a tampered payload, want error")
+	}
+}
+
+func TestEnvelopeFromMetablockRoundTrip(t *testing.T) {
+	link := Link{
+		Type: "link",
+		Name: "test",
+		Products: map[string]interface{}{
+			"foo": map[string]interface{}{"sha256": "abc123"},
+		},
+	}
+	mb := Metablock{Signed: link}
+
+	env, err := EnvelopeFromMetablock(mb, PayloadTypeLink)
+	if err != nil {
+		t.Fatalf("EnvelopeFromMetablock() failed: %v", err)
+	}
+	if env.PayloadType != PayloadTypeLink {
+		t.Errorf("PayloadType = %q, want %q", env.PayloadType, PayloadTypeLink)
+	}
+
+	roundTripped, err := env.ToMetablock()
+	if err != nil {
+		t.Fatalf("ToMetablock() failed: %v", err)
+	}
+
+	roundTrippedLink, ok := roundTripped.Signed.(Link)
+	if !ok {
+		t.Fatalf("roundTripped.Signed is %T, want Link", roundTripped.Signed)
+	}
+	if roundTrippedLink.Name != link.Name {
+		t.Errorf("roundTripped link name = %q, want %q", roundTrippedLink.Name, link.Name)
+	}
+}
+
+func TestIsEnvelope(t *testing.T) {
+	tables := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"envelope", []byte(`{"payloadType":"x","payload":"eA==","signatures":[]}`), true},
+		{"metablock", []byte(`{"signed":{},"signatures":[]}`), false},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			var raw map[string]*json.RawMessage
+			if err := json.Unmarshal(table.data, &raw); err != nil {
+				t.Fatalf("failed to unmarshal test fixture: %v", err)
+			}
+			if got := isEnvelope(raw); got != table.want {
+				t.Errorf("isEnvelope() = %v, want %v", got, table.want)
+			}
+		})
+	}
+}
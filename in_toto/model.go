@@ -128,6 +128,13 @@ type Layout struct {
 	Keys    map[string]Key `json:"keys"`
 	Expires string         `json:"expires"`
 	Readme  string         `json:"readme"`
+
+	// Delegations and SuccinctRoles let a Layout scale authority over a
+	// Step or Inspection beyond what its own flat Keys/Threshold can
+	// express. See ResolveFunctionaries. Both are optional; a Layout that
+	// sets neither keeps the original flat-Keys behavior.
+	Delegations   []Delegation   `json:"delegations,omitempty"`
+	SuccinctRoles *SuccinctRoles `json:"succinct_roles,omitempty"`
 }
 
 // Go does not allow to pass `[]T` (slice with certain type) to a function
@@ -169,12 +176,18 @@ type Metablock struct {
 	// turn out to be a layout (sublayout)
 	Signed     interface{} `json:"signed"`
 	Signatures []Signature `json:"signatures"`
+
+	// canonicalizer is the Canonicalizer used by GetSignableRepresentation.
+	// It is unexported and not part of the JSON representation; set it via
+	// SetCanonicalizer. When nil, the package-wide default is used.
+	canonicalizer Canonicalizer
 }
 
 /*
 Load parses JSON formatted metadata at the passed path into the Metablock
 object on which it was called.  It returns an error if it cannot parse
-a valid JSON formatted Metablock that contains a Link or Layout.
+a valid JSON formatted Metablock that contains a Link, Layout or Statement.
+Load always assumes FormatJSON; use LoadAs for other Formats.
 */
 func (mb *Metablock) Load(path string) error {
 	// Open file and close before returning
@@ -190,6 +203,16 @@ func (mb *Metablock) Load(path string) error {
 		return err
 	}
 
+	return mb.loadBytes(jsonBytes)
+}
+
+/*
+loadBytes parses the passed JSON formatted bytes into the Metablock on which
+it was called. It contains the logic shared by Load, which reads those bytes
+from a file, and LoadAs, which may have decoded them from a non-JSON Format
+first.
+*/
+func (mb *Metablock) loadBytes(jsonBytes []byte) error {
 	// Unmarshal JSON into a map of raw messages (signed and signatures)
 	// We can't fully unmarshal immediately, because we need to inspect the
 	// type (link or layout) to decide which data structure to use
@@ -232,9 +255,16 @@ func (mb *Metablock) Load(path string) error {
 		}
 		mb.Signed = layout
 
+	} else if signed["_type"] == StatementType {
+		statement, err := unmarshalStatement(*rawMb["signed"])
+		if err != nil {
+			return err
+		}
+		mb.Signed = statement
+
 	} else {
 		return fmt.Errorf("The '_type' field of the 'signed' part of in-toto" +
-			" metadata must be one of 'link' or 'layout'")
+			" metadata must be one of 'link', 'layout' or '%s'", StatementType)
 	}
 
 	return nil
@@ -243,10 +273,10 @@ func (mb *Metablock) Load(path string) error {
 /*
 Dump JSON serializes and writes the Metablock on which it was called to the
 passed path.  It returns an error if JSON serialization or writing fails.
+Dump always uses FormatJSON; use DumpAs for other Formats.
 */
 func (mb *Metablock) Dump(path string) error {
 	// JSON encode Metablock formatted with newlines and indentation
-	// TODO: parametrize format
 	jsonBytes, err := json.MarshalIndent(mb, "", "  ")
 	if err != nil {
 		return err
@@ -262,12 +292,17 @@ func (mb *Metablock) Dump(path string) error {
 }
 
 /*
-GetSignableRepresentation returns the canonical JSON representation of the
-Signed field of the Metablock on which it was called.  If canonicalization
-fails the first return value is nil and the second return value is the error.
+GetSignableRepresentation returns the canonical representation of the Signed
+field of the Metablock on which it was called, using the Canonicalizer set
+via SetCanonicalizer or, if none was set, the package-wide default (see
+SetDefaultCanonicalizer). If canonicalization fails the first return value is
+nil and the second return value is the error.
 */
 func (mb *Metablock) GetSignableRepresentation() ([]byte, error) {
-	return encodeCanonical(mb.Signed)
+	if mb.canonicalizer != nil {
+		return mb.canonicalizer.Canonicalize(mb.Signed)
+	}
+	return defaultCanonicalizer.Canonicalize(mb.Signed)
 }
 
 /*
@@ -295,36 +330,37 @@ func (mb *Metablock) VerifySignature(key Key) error {
 		return err
 	}
 
-	if err := VerifySignature(key, sig, dataCanonical); err != nil {
+	verifier, err := newVerifier(key)
+	if err != nil {
 		return err
 	}
-	return nil
+
+	return verifier.Verify(dataCanonical, sig)
 }
 
 /*
 Sign signs the signed portion of the metablock using the Key object provided.
 It then appends the resulting signature to the signatures field as provided.
 It return an error if the Signed object cannot be canonicalized, or if the key
-is invalid or not supported.
+is invalid or not supported. Sign looks up a Signer for the key's KeyType
+and Scheme in the built-in registry first, falling back to any plugin
+discovered by the default PluginManager. Callers that already hold a Signer,
+e.g. for a KMS-backed key, should use SignWithSigner instead.
 */
 func (mb *Metablock) Sign(key Key) error {
-
 	dataCanonical, err := mb.GetSignableRepresentation()
 	if err != nil {
 		return err
 	}
-	var newSignature Signature
 
-	// FIXME: we could be fancier about signature-generation using a dispatch
-	// table or something but for now let's just be explicit
-	// (also, lolnogenerics)
-	if key.KeyType == "ed25519" && key.Scheme == "ed25519" {
-		newSignature, err = generateEd25519Signature(dataCanonical, key)
-		if err != nil {
-			return err
-		}
-	} else {
-		return fmt.Errorf("This key type or signature scheme is not supported yet!")
+	signer, err := newSigner(key)
+	if err != nil {
+		return err
+	}
+
+	newSignature, err := signer.Sign(dataCanonical)
+	if err != nil {
+		return err
 	}
 
 	mb.Signatures = append(mb.Signatures, newSignature)
@@ -0,0 +1,71 @@
+package in_toto
+
+/*
+Predicate type constants for the built-in predicates this package ships.
+Callers wishing to register their own predicate types should use
+RegisterPredicate instead of adding to this file.
+*/
+const (
+	PredicateSLSAProvenance = "https://slsa.dev/provenance/v0.2"
+	PredicateSPDX           = "https://spdx.dev/Document"
+)
+
+/*
+ProvenanceBuilder identifies the entity that executed the build step,
+described by the SLSA Provenance predicate.
+*/
+type ProvenanceBuilder struct {
+	ID string `json:"id"`
+}
+
+/*
+ProvenanceInvocation describes how the build was invoked, i.e. the
+ConfigSource it was triggered from, the Parameters it was given and the
+Environment it ran in.
+*/
+type ProvenanceInvocation struct {
+	ConfigSource map[string]interface{} `json:"configSource,omitempty"`
+	Parameters   interface{}            `json:"parameters,omitempty"`
+	Environment  interface{}            `json:"environment,omitempty"`
+}
+
+/*
+ProvenanceMaterial identifies one of the artifacts that went into the build,
+by URI and, optionally, digest.
+*/
+type ProvenanceMaterial struct {
+	URI    string    `json:"uri"`
+	Digest DigestSet `json:"digest,omitempty"`
+}
+
+/*
+ProvenancePredicate implements the SLSA Provenance v0.2 predicate
+(https://slsa.dev/provenance/v0.2), recording how a build was produced:
+which Builder ran it, how it was Invoked, and which Materials it consumed.
+It is registered under PredicateSLSAProvenance.
+*/
+type ProvenancePredicate struct {
+	Builder    ProvenanceBuilder    `json:"builder"`
+	BuildType  string               `json:"buildType"`
+	Invocation ProvenanceInvocation `json:"invocation,omitempty"`
+	Materials  []ProvenanceMaterial `json:"materials,omitempty"`
+}
+
+/*
+SPDXPredicate wraps a full SPDX document (https://spdx.dev) as an in-toto
+attestation predicate, so SBOMs generated by existing SPDX tooling can be
+attached to a Subject without reshaping them. It is registered under
+PredicateSPDX.
+*/
+type SPDXPredicate struct {
+	SPDXDocument map[string]interface{} `json:"spdx"`
+}
+
+func init() {
+	RegisterPredicate(PredicateSLSAProvenance, func() interface{} {
+		return &ProvenancePredicate{}
+	})
+	RegisterPredicate(PredicateSPDX, func() interface{} {
+		return &SPDXPredicate{}
+	})
+}
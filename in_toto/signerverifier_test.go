@@ -0,0 +1,128 @@
+package in_toto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func rsaTestKey(t *testing.T) Key {
+	t.Helper()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	privPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(priv),
+	})
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal RSA public key: %v", err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})
+
+	return Key{
+		KeyId:   "test-rsa-key",
+		KeyType: "rsa",
+		Scheme:  "rsassa-pss-sha256",
+		KeyVal:  KeyVal{Private: string(privPEM), Public: string(pubPEM)},
+	}
+}
+
+func ecdsaTestKey(t *testing.T) Key {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+
+	privBytes, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("failed to marshal ECDSA private key: %v", err)
+	}
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privBytes})
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal ECDSA public key: %v", err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})
+
+	return Key{
+		KeyId:   "test-ecdsa-key",
+		KeyType: "ecdsa",
+		Scheme:  "ecdsa-sha2-nistp256",
+		KeyVal:  KeyVal{Private: string(privPEM), Public: string(pubPEM)},
+	}
+}
+
+func TestSignerVerifierRoundTrip(t *testing.T) {
+	tables := []struct {
+		name string
+		key  Key
+	}{
+		{"rsa", rsaTestKey(t)},
+		{"ecdsa", ecdsaTestKey(t)},
+	}
+
+	payload := []byte("signable payload")
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			signer, err := newSigner(table.key)
+			if err != nil {
+				t.Fatalf("newSigner() failed: %v", err)
+			}
+			if signer.Scheme() != table.key.Scheme {
+				t.Errorf("Scheme() = %q, want %q", signer.Scheme(), table.key.Scheme)
+			}
+
+			sig, err := signer.Sign(payload)
+			if err != nil {
+				t.Fatalf("Sign() failed: %v", err)
+			}
+
+			verifier, err := newVerifier(table.key)
+			if err != nil {
+				t.Fatalf("newVerifier() failed: %v", err)
+			}
+
+			if err := verifier.Verify(payload, sig); err != nil {
+				t.Errorf("Verify() failed on a freshly signed payload: %v", err)
+			}
+
+			if err := verifier.Verify([]byte("a different payload"), sig); err == nil {
+				t.Errorf("Verify() succeeded against a mismatched payload, want error")
+			}
+		})
+	}
+}
+
+func TestNewSignerUnknownSchemeFallsBackToPlugins(t *testing.T) {
+	key := Key{KeyId: "no-such-key", KeyType: "nonexistent-plugin", Scheme: "nonexistent-scheme"}
+
+	if _, err := newSigner(key); err == nil {
+		t.Errorf("newSigner() succeeded for a key with no registered signer and no plugin, want error")
+	}
+	if _, err := newVerifier(key); err == nil {
+		t.Errorf("newVerifier() succeeded for a key with no registered verifier and no plugin, want error")
+	}
+}
+
+func TestPluginManagerDescribeKeyRequiresGetMetadata(t *testing.T) {
+	pm := NewPluginManager(t.TempDir())
+	key := Key{KeyId: "test-key", KeyType: "missing-plugin"}
+
+	if _, err := pm.describeKey(key); err == nil {
+		t.Errorf("describeKey() succeeded for a plugin directory with no executable, want error")
+	}
+}
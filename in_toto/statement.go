@@ -0,0 +1,145 @@
+package in_toto
+
+import "encoding/json"
+
+/*
+StatementType is the fixed "_type" of every Statement, regardless of the
+predicate it carries.
+*/
+const StatementType = "https://in-toto.io/Statement/v0.1"
+
+/*
+DigestSet captures a set of digests of a Subject, keyed by algorithm name
+(e.g. "sha256") and recorded as a lowercase hex string, as defined by the
+in-toto Attestation Framework.
+*/
+type DigestSet map[string]string
+
+/*
+Subject identifies an artifact that a Statement's Predicate makes claims
+about, by name and by one or more digests.
+*/
+type Subject struct {
+	Name   string    `json:"name"`
+	Digest DigestSet `json:"digest"`
+}
+
+/*
+Statement implements the in-toto Attestation Framework
+(https://github.com/in-toto/attestation), a generic, typed alternative to
+Link for recording claims about a set of Subjects. Predicate holds the
+type-specific body of the attestation, e.g. SLSA Provenance or an SBOM,
+identified by PredicateType.
+*/
+type Statement struct {
+	Type          string      `json:"_type"`
+	Subject       []Subject   `json:"subject"`
+	PredicateType string      `json:"predicateType"`
+	Predicate     interface{} `json:"predicate"`
+}
+
+/*
+predicateFactories maps a PredicateType to a constructor for the concrete Go
+type that its Predicate should be unmarshaled into. Callers register their
+own predicate types via RegisterPredicate; this package registers the
+built-in types it ships (see predicates.go).
+*/
+var predicateFactories = map[string]func() interface{}{}
+
+/*
+RegisterPredicate associates a PredicateType with a factory function that
+returns a pointer to the concrete Go type that Statement.Predicate should be
+unmarshaled into whenever that PredicateType is encountered, e.g. in
+Metablock.Load. Callers should call RegisterPredicate from an init function
+before parsing any attestations of their custom predicate type.
+*/
+func RegisterPredicate(predicateType string, factory func() interface{}) {
+	predicateFactories[predicateType] = factory
+}
+
+/*
+unmarshalStatement unmarshals the passed raw Statement JSON into a Statement,
+using the registry populated via RegisterPredicate to pick a concrete type
+for Predicate based on predicateType. If no factory is registered for the
+predicateType, Predicate is left as a generic map[string]interface{}.
+*/
+func unmarshalStatement(data []byte) (Statement, error) {
+	var stmt Statement
+	if err := json.Unmarshal(data, &stmt); err != nil {
+		return Statement{}, err
+	}
+
+	factory, ok := predicateFactories[stmt.PredicateType]
+	if !ok {
+		return stmt, nil
+	}
+
+	// Re-unmarshal just the predicate field into the registered concrete type.
+	var raw struct {
+		Predicate json.RawMessage `json:"predicate"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Statement{}, err
+	}
+
+	predicate := factory()
+	if err := json.Unmarshal(raw.Predicate, predicate); err != nil {
+		return Statement{}, err
+	}
+	stmt.Predicate = predicate
+
+	return stmt, nil
+}
+
+/*
+LinkToStatement migrates the passed Link's Materials and Products into a
+Statement with the passed predicateType, so that existing Link-producing
+functionaries can be moved onto the Attestation Framework without having to
+change how they collect supply chain evidence. The Link itself, including
+its Command, ByProducts and Environment, becomes the Statement's Predicate.
+*/
+func LinkToStatement(link Link, predicateType string) Statement {
+	subjects := make([]Subject, 0, len(link.Products))
+	for name, digest := range link.Products {
+		digestSet := DigestSet{}
+		if m, ok := digest.(map[string]interface{}); ok {
+			for alg, hash := range m {
+				if s, ok := hash.(string); ok {
+					digestSet[alg] = s
+				}
+			}
+		}
+		subjects = append(subjects, Subject{Name: name, Digest: digestSet})
+	}
+
+	return Statement{
+		Type:          StatementType,
+		Subject:       subjects,
+		PredicateType: predicateType,
+		Predicate:     link,
+	}
+}
+
+/*
+SubjectArtifacts is the inverse of the map LinkToStatement builds from
+Link.Products: it turns Subject back into a map[string]interface{} shaped
+like Link.Materials/Link.Products (artifact name -> {algorithm: hex
+digest}), so that artifact rule verification, which is written against that
+shape, works transparently against a Statement's Subject the same way it
+works against a Link's Materials/Products.
+
+This package does not ship a verification engine to call SubjectArtifacts
+from; it is the extension point such an engine is expected to use once one
+exists, exactly as Link's own Materials/Products are consumed today.
+*/
+func (s Statement) SubjectArtifacts() map[string]interface{} {
+	artifacts := make(map[string]interface{}, len(s.Subject))
+	for _, subject := range s.Subject {
+		digest := make(map[string]interface{}, len(subject.Digest))
+		for alg, hash := range subject.Digest {
+			digest[alg] = hash
+		}
+		artifacts[subject.Name] = digest
+	}
+	return artifacts
+}
@@ -0,0 +1,142 @@
+package in_toto
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type customTestPredicate struct {
+	Foo string `json:"foo"`
+}
+
+func TestUnmarshalStatementPredicateDispatch(t *testing.T) {
+	RegisterPredicate("https://example.com/TestPredicate/v1", func() interface{} {
+		return &customTestPredicate{}
+	})
+
+	tables := []struct {
+		name          string
+		predicateType string
+		predicateJSON string
+		check         func(t *testing.T, stmt Statement)
+	}{
+		{
+			name:          "registered SLSA provenance predicate",
+			predicateType: PredicateSLSAProvenance,
+			predicateJSON: `{"builder":{"id":"https://example.com/builder"},"buildType":"test"}`,
+			check: func(t *testing.T, stmt Statement) {
+				pred, ok := stmt.Predicate.(*ProvenancePredicate)
+				if !ok {
+					t.Fatalf("Predicate is %T, want *ProvenancePredicate", stmt.Predicate)
+				}
+				if pred.Builder.ID != "https://example.com/builder" {
+					t.Errorf("Builder.ID = %q, want %q", pred.Builder.ID, "https://example.com/builder")
+				}
+			},
+		},
+		{
+			name:          "registered custom predicate",
+			predicateType: "https://example.com/TestPredicate/v1",
+			predicateJSON: `{"foo":"bar"}`,
+			check: func(t *testing.T, stmt Statement) {
+				pred, ok := stmt.Predicate.(*customTestPredicate)
+				if !ok {
+					t.Fatalf("Predicate is %T, want *customTestPredicate", stmt.Predicate)
+				}
+				if pred.Foo != "bar" {
+					t.Errorf("Foo = %q, want %q", pred.Foo, "bar")
+				}
+			},
+		},
+		{
+			name:          "unregistered predicate falls back to a generic map",
+			predicateType: "https://example.com/Unregistered/v1",
+			predicateJSON: `{"foo":"bar"}`,
+			check: func(t *testing.T, stmt Statement) {
+				if _, ok := stmt.Predicate.(map[string]interface{}); !ok {
+					t.Fatalf("Predicate is %T, want map[string]interface{}", stmt.Predicate)
+				}
+			},
+		},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			data, err := json.Marshal(map[string]interface{}{
+				"_type":         StatementType,
+				"subject":       []Subject{{Name: "foo", Digest: DigestSet{"sha256": "abc"}}},
+				"predicateType": table.predicateType,
+				"predicate":     json.RawMessage(table.predicateJSON),
+			})
+			if err != nil {
+				t.Fatalf("failed to build test fixture: %v", err)
+			}
+
+			stmt, err := unmarshalStatement(data)
+			if err != nil {
+				t.Fatalf("unmarshalStatement() failed: %v", err)
+			}
+			if stmt.PredicateType != table.predicateType {
+				t.Errorf("PredicateType = %q, want %q", stmt.PredicateType, table.predicateType)
+			}
+			table.check(t, stmt)
+		})
+	}
+}
+
+func TestLinkToStatement(t *testing.T) {
+	link := Link{
+		Type: "link",
+		Name: "build",
+		Products: map[string]interface{}{
+			"foo.tar.gz": map[string]interface{}{"sha256": "abc123"},
+		},
+	}
+
+	stmt := LinkToStatement(link, PredicateSLSAProvenance)
+
+	if stmt.Type != StatementType {
+		t.Errorf("Type = %q, want %q", stmt.Type, StatementType)
+	}
+	if stmt.PredicateType != PredicateSLSAProvenance {
+		t.Errorf("PredicateType = %q, want %q", stmt.PredicateType, PredicateSLSAProvenance)
+	}
+	if len(stmt.Subject) != 1 {
+		t.Fatalf("expected 1 subject, got %d", len(stmt.Subject))
+	}
+	if stmt.Subject[0].Name != "foo.tar.gz" {
+		t.Errorf("Subject name = %q, want %q", stmt.Subject[0].Name, "foo.tar.gz")
+	}
+	if stmt.Subject[0].Digest["sha256"] != "abc123" {
+		t.Errorf("Subject digest = %q, want %q", stmt.Subject[0].Digest["sha256"], "abc123")
+	}
+
+	predLink, ok := stmt.Predicate.(Link)
+	if !ok {
+		t.Fatalf("Predicate is %T, want Link", stmt.Predicate)
+	}
+	if predLink.Name != link.Name {
+		t.Errorf("Predicate.Name = %q, want %q", predLink.Name, link.Name)
+	}
+}
+
+func TestSubjectArtifactsRoundTripsThroughLinkToStatement(t *testing.T) {
+	link := Link{
+		Type: "link",
+		Name: "build",
+		Products: map[string]interface{}{
+			"foo.tar.gz": map[string]interface{}{"sha256": "abc123"},
+		},
+	}
+
+	stmt := LinkToStatement(link, PredicateSLSAProvenance)
+	artifacts := stmt.SubjectArtifacts()
+
+	digest, ok := artifacts["foo.tar.gz"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("artifacts[%q] is %T, want map[string]interface{}", "foo.tar.gz", artifacts["foo.tar.gz"])
+	}
+	if digest["sha256"] != "abc123" {
+		t.Errorf("digest[sha256] = %v, want %q", digest["sha256"], "abc123")
+	}
+}